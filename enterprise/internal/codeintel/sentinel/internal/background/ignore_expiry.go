@@ -0,0 +1,49 @@
+// Package background runs periodic maintenance jobs for the sentinel
+// vulnerability matching subsystem.
+package background
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/sourcegraph/sourcegraph/internal/goroutine"
+)
+
+// expiringSoonWindow is how far ahead an ignore rule's expires_at is
+// considered "expiring soon" by the gauge below.
+const expiringSoonWindow = 7 * 24 * time.Hour
+
+var expiringIgnoreRulesGauge = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "src_codeintel_sentinel_ignore_rules_expiring_soon_total",
+	Help: "Number of vulnerability match ignore rules whose expires_at falls within the next 7 days.",
+})
+
+// IgnoreRuleCounter is the minimal surface NewIgnoreRuleExpiryReporter needs
+// from the sentinel store; it's declared here rather than imported so this
+// package has no dependency on store's concrete type.
+type IgnoreRuleCounter interface {
+	CountIgnoreRulesExpiringSoon(ctx context.Context, window time.Duration) (int, error)
+}
+
+// NewIgnoreRuleExpiryReporter periodically counts ignore rules expiring
+// within the next 7 days and publishes the count as a gauge, so an accepted
+// exception that's about to lapse shows up on a dashboard before its match
+// silently resurfaces.
+func NewIgnoreRuleExpiryReporter(store IgnoreRuleCounter, interval time.Duration) goroutine.BackgroundRoutine {
+	return goroutine.NewPeriodicGoroutine(
+		context.Background(),
+		interval,
+		goroutine.NewHandlerWithErrorMessage("codeintel.sentinel.ignore-rule-expiry-reporter", func(ctx context.Context) error {
+			count, err := store.CountIgnoreRulesExpiringSoon(ctx, expiringSoonWindow)
+			if err != nil {
+				return err
+			}
+
+			expiringIgnoreRulesGauge.Set(float64(count))
+			return nil
+		}),
+	)
+}
@@ -0,0 +1,20 @@
+package matcher
+
+import (
+	"context"
+
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/codeintel/sentinel/internal/versionmatch"
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/codeintel/sentinel/shared"
+)
+
+// Gomod matches Go module dependencies, identified in SCIP indexes by the
+// "gomod" scheme.
+type Gomod struct{}
+
+func (Gomod) Type() string      { return "gomod" }
+func (Gomod) Schemes() []string { return []string{"gomod"} }
+
+func (Gomod) Match(ctx context.Context, tx Queryer, vap shared.AffectedPackage, upload Upload) ([]Result, error) {
+	ecosystem, _ := versionmatch.Lookup("go")
+	return matchVersion(ecosystem, vap, upload), nil
+}
@@ -0,0 +1,41 @@
+package matcher
+
+import (
+	"github.com/sourcegraph/log"
+
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/codeintel/sentinel/internal/versionmatch"
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/codeintel/sentinel/shared"
+)
+
+var logger = log.Scoped("sentinel.matcher")
+
+// matchVersion is shared by every ecosystem Matcher: parse upload's version
+// and vap's affected range with ecosystem, then report a Result if and only
+// if the version falls inside the range. Version or range parse failures
+// are surfaced as an unknown-confidence match rather than silently dropped,
+// and a warning is logged so un-parseable versions are visible instead of
+// vanishing.
+func matchVersion(ecosystem versionmatch.Ecosystem, vap shared.AffectedPackage, upload Upload) []Result {
+	base := Result{UploadID: upload.ID, VulnerabilityAffectedPackageID: vap.ID}
+
+	v, err := ecosystem.Parse(upload.Version)
+	if err != nil {
+		logger.Warn("vulnerability match: could not parse dependency version", log.String("version", upload.Version), log.Error(err))
+		base.Confidence = ConfidenceUnknown
+		return []Result{base}
+	}
+
+	constraint, err := versionmatch.ParseConstraintEvents(vap.VersionConstraint)
+	if err != nil {
+		logger.Warn("vulnerability match: could not parse affected range", log.Error(err))
+		base.Confidence = ConfidenceUnknown
+		return []Result{base}
+	}
+
+	if !ecosystem.Check(v, constraint) {
+		return nil
+	}
+
+	base.Confidence = ConfidenceHigh
+	return []Result{base}
+}
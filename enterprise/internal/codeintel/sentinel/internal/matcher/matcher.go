@@ -0,0 +1,132 @@
+// Package matcher decides, per SCIP package manager scheme, whether an
+// upload's dependency on a package actually matches a vulnerability's
+// affected range. It replaces a single hardcoded scheme-to-language map
+// with one Matcher implementation per ecosystem, modeled after Grype's
+// per-ecosystem matcher layout: adding support for a new package manager is
+// a new file implementing Matcher, not another line in a map.
+package matcher
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/keegancsmith/sqlf"
+
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/codeintel/sentinel/shared"
+	"github.com/sourcegraph/sourcegraph/internal/database/basestore"
+)
+
+// Queryer is satisfied by both *basestore.Store and an open transaction;
+// it's the minimal surface a Matcher needs to issue its own ecosystem-
+// specific lookups (e.g. Maven's shaded-jar coordinate resolution).
+type Queryer interface {
+	Query(ctx context.Context, query *sqlf.Query) (basestore.Rows, error)
+}
+
+// Upload is the subset of an LSIF upload's dependency edge a Matcher needs
+// to decide whether it matches a vulnerability's affected package.
+type Upload struct {
+	ID      int
+	Scheme  string
+	Name    string
+	Version string
+}
+
+// Confidence mirrors the store package's match_confidence values; it's
+// re-declared here so this package has no dependency on store (store
+// depends on matcher, not the other way around).
+const (
+	ConfidenceHigh    = "high"
+	ConfidenceUnknown = "unknown"
+)
+
+// Result is a single (upload, affected package) pair a Matcher has judged
+// to be a match, tagged with enough provenance to dedupe it against the
+// same pair produced by a different matcher.
+type Result struct {
+	UploadID                       int
+	VulnerabilityAffectedPackageID int
+	Confidence                     string
+	MatcherType                    string
+	Fingerprint                    string
+}
+
+// Matcher implements vulnerability matching for a single ecosystem.
+type Matcher interface {
+	// Type identifies this matcher in a Result's MatcherType field, e.g.
+	// "gomod", "npm", "pypi", "maven", "stock".
+	Type() string
+	// Schemes lists the SCIP package manager schemes this matcher handles
+	// (e.g. "gomod", "npm", "semanticdb-maven").
+	Schemes() []string
+	// Match reports the Results (zero or one, today) between upload and
+	// vap, given upload.Scheme is one of Schemes().
+	Match(ctx context.Context, tx Queryer, vap shared.AffectedPackage, upload Upload) ([]Result, error)
+}
+
+// Controller dispatches uploads to the Matcher registered for their SCIP
+// scheme, falling back to a stock matcher for schemes nothing else claims.
+type Controller struct {
+	bySchemes map[string]Matcher
+	stock     Matcher
+}
+
+// NewController registers matchers by their declared Schemes(). Later
+// matchers in the list win scheme collisions; stock is used as the
+// catch-all and need not declare any schemes of its own.
+func NewController(stock Matcher, matchers ...Matcher) *Controller {
+	c := &Controller{bySchemes: map[string]Matcher{}, stock: stock}
+	for _, m := range matchers {
+		for _, scheme := range m.Schemes() {
+			c.bySchemes[scheme] = m
+		}
+	}
+
+	return c
+}
+
+// MatcherForScheme returns the Matcher registered for scheme, or the stock
+// fallback matcher if none is registered.
+func (c *Controller) MatcherForScheme(scheme string) Matcher {
+	if m, ok := c.bySchemes[scheme]; ok {
+		return m
+	}
+
+	return c.stock
+}
+
+// Dispatch routes upload to the matcher registered for its scheme (or
+// stock) and returns its Results, stamped with that matcher's Type and a
+// Fingerprint so repeat reports of the same match dedupe cleanly
+// downstream.
+func (c *Controller) Dispatch(ctx context.Context, tx Queryer, vap shared.AffectedPackage, upload Upload) ([]Result, error) {
+	m := c.MatcherForScheme(upload.Scheme)
+
+	results, err := m.Match(ctx, tx, vap, upload)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range results {
+		results[i].MatcherType = m.Type()
+		results[i].Fingerprint = Fingerprint(upload.ID, results[i].VulnerabilityAffectedPackageID, vap.PackageName, upload.Version, m.Type())
+	}
+
+	return results, nil
+}
+
+// Fingerprint hashes the identity of a match so that duplicate matches
+// produced by the same matcher (e.g. by re-running ScanMatches) dedupe
+// cleanly instead of producing repeat rows. matcherType is part of the
+// hash, so results from two different matchers never share a
+// fingerprint. uploadID is included so two
+// uploads that happen to depend on the same vulnerable package at the same
+// version (e.g. two repos both on lodash@4.17.15) get distinct fingerprints
+// instead of colliding into a single row.
+func Fingerprint(uploadID, vulnerabilityAffectedPackageID int, packageName, version, matcherType string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\x00%d\x00%s\x00%s\x00%s", uploadID, vulnerabilityAffectedPackageID, packageName, version, matcherType)
+	return hex.EncodeToString(h.Sum(nil))
+}
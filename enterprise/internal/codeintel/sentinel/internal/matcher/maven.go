@@ -0,0 +1,54 @@
+package matcher
+
+import (
+	"context"
+
+	"github.com/keegancsmith/sqlf"
+
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/codeintel/sentinel/internal/versionmatch"
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/codeintel/sentinel/shared"
+	"github.com/sourcegraph/sourcegraph/internal/database/basestore"
+)
+
+// Maven matches Java dependencies addressed by Maven groupId:artifactId
+// coordinates, identified in SCIP indexes by the "semanticdb-maven" scheme.
+type Maven struct{}
+
+func (Maven) Type() string      { return "maven" }
+func (Maven) Schemes() []string { return []string{"semanticdb-maven"} }
+
+func (m Maven) Match(ctx context.Context, tx Queryer, vap shared.AffectedPackage, upload Upload) ([]Result, error) {
+	if !m.coordinatesMatch(ctx, tx, vap, upload) {
+		return nil, nil
+	}
+
+	ecosystem, _ := versionmatch.Lookup("maven")
+	return matchVersion(ecosystem, vap, upload), nil
+}
+
+// coordinatesMatch reports whether upload's coordinates name vap's package,
+// either directly or, for shaded/relocated jars whose artifact id no longer
+// matches the original package name, via the shaded-jar coordinate lookup
+// populated from Maven Central's shade-plugin metadata.
+func (m Maven) coordinatesMatch(ctx context.Context, tx Queryer, vap shared.AffectedPackage, upload Upload) bool {
+	if upload.Name == vap.PackageName {
+		return true
+	}
+
+	canonical, ok, err := m.lookupShadedCoordinates(ctx, tx, upload.Name)
+	if err != nil || !ok {
+		return false
+	}
+
+	return canonical == vap.PackageName
+}
+
+func (Maven) lookupShadedCoordinates(ctx context.Context, tx Queryer, artifactCoordinates string) (string, bool, error) {
+	return basestore.ScanFirstString(tx.Query(ctx, sqlf.Sprintf(mavenShadedCoordinatesQuery, artifactCoordinates)))
+}
+
+const mavenShadedCoordinatesQuery = `
+SELECT canonical_coordinates
+FROM maven_shaded_coordinates
+WHERE shaded_coordinates = %s
+`
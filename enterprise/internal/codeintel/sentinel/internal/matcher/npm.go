@@ -0,0 +1,20 @@
+package matcher
+
+import (
+	"context"
+
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/codeintel/sentinel/internal/versionmatch"
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/codeintel/sentinel/shared"
+)
+
+// NPM matches npm package dependencies, identified in SCIP indexes by the
+// "npm" scheme.
+type NPM struct{}
+
+func (NPM) Type() string      { return "npm" }
+func (NPM) Schemes() []string { return []string{"npm"} }
+
+func (NPM) Match(ctx context.Context, tx Queryer, vap shared.AffectedPackage, upload Upload) ([]Result, error) {
+	ecosystem, _ := versionmatch.Lookup("npm")
+	return matchVersion(ecosystem, vap, upload), nil
+}
@@ -0,0 +1,20 @@
+package matcher
+
+import (
+	"context"
+
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/codeintel/sentinel/internal/versionmatch"
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/codeintel/sentinel/shared"
+)
+
+// PyPI matches Python package dependencies, identified in SCIP indexes by
+// the "pip" scheme.
+type PyPI struct{}
+
+func (PyPI) Type() string      { return "pypi" }
+func (PyPI) Schemes() []string { return []string{"pip"} }
+
+func (PyPI) Match(ctx context.Context, tx Queryer, vap shared.AffectedPackage, upload Upload) ([]Result, error) {
+	ecosystem, _ := versionmatch.Lookup("pypi")
+	return matchVersion(ecosystem, vap, upload), nil
+}
@@ -0,0 +1,25 @@
+package matcher
+
+import (
+	"context"
+
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/codeintel/sentinel/shared"
+)
+
+// Stock is the catch-all matcher for schemes with no dedicated ecosystem
+// matcher registered. It can't validate the dependency's version against
+// the affected range (it doesn't know how to parse or order that
+// ecosystem's versions), so it reports the package-name match found by the
+// candidate query as-is, at unknown confidence, rather than dropping it.
+type Stock struct{}
+
+func (Stock) Type() string      { return "stock" }
+func (Stock) Schemes() []string { return nil }
+
+func (Stock) Match(ctx context.Context, tx Queryer, vap shared.AffectedPackage, upload Upload) ([]Result, error) {
+	return []Result{{
+		UploadID:                       upload.ID,
+		VulnerabilityAffectedPackageID: vap.ID,
+		Confidence:                     ConfidenceUnknown,
+	}}, nil
+}
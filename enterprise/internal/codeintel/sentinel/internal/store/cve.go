@@ -0,0 +1,101 @@
+package store
+
+import (
+	"fmt"
+
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/codeintel/sentinel/shared"
+)
+
+// sourcePriority orders advisory sources so that when two sources disagree
+// on the fixed-in version or version constraint of the same CVE we have a
+// deterministic way to pick one (per-source severity stays in Sources): NVD is the most authoritative, then
+// GHSA, then whatever the upstream ecosystem advisory says.
+var sourcePriority = map[string]int{
+	"nvd":       0,
+	"ghsa":      1,
+	"ecosystem": 2,
+}
+
+func rank(sourceKind string) int {
+	if p, ok := sourcePriority[sourceKind]; ok {
+		return p
+	}
+
+	// Unknown sources are least trusted, but still surfaced.
+	return len(sourcePriority)
+}
+
+// unranked marks a CVE group as not yet having a source to tie-break
+// against, so the first source with a known rank always wins regardless of
+// sourcePriority order.
+const unranked = -1
+
+// mergeByCVE collapses matches that share the same upload, affected package,
+// and canonical CVE into a single match per CVE, merging their per-source
+// fields into Sources. Matches with no CVE alias (CVE == "") are left alone,
+// since there is nothing to orient them by.
+func mergeByCVE(matches []shared.VulnerabilityMatch) []shared.VulnerabilityMatch {
+	order := make([]string, 0, len(matches))
+	byKey := make(map[string]*shared.VulnerabilityMatch, len(matches))
+	// bestRank tracks the rank of whichever source is currently winning
+	// each group's scalar fields, so later arrivals are compared against
+	// the best seen so far rather than against whichever source happened
+	// to create the group first.
+	bestRank := make(map[string]int, len(matches))
+	passthrough := make([]shared.VulnerabilityMatch, 0)
+
+	for _, m := range matches {
+		if m.CVE == "" {
+			passthrough = append(passthrough, m)
+			continue
+		}
+
+		key := cveGroupKey(m)
+		existing, ok := byKey[key]
+		if !ok {
+			copied := m
+			byKey[key] = &copied
+			bestRank[key] = unranked
+			existing = &copied
+			order = append(order, key)
+		} else {
+			existing.Sources = append(existing.Sources, m.Sources...)
+		}
+
+		if newRank, win := preferred(m, bestRank[key]); win {
+			bestRank[key] = newRank
+			existing.AffectedPackage.FixedIn = m.AffectedPackage.FixedIn
+			existing.AffectedPackage.VersionConstraint = m.AffectedPackage.VersionConstraint
+		}
+	}
+
+	merged := make([]shared.VulnerabilityMatch, 0, len(order)+len(passthrough))
+	for _, key := range order {
+		merged = append(merged, *byKey[key])
+	}
+
+	return append(merged, passthrough...)
+}
+
+// cveGroupKey identifies matches that should be merged: same upload, same
+// affected package, same canonical CVE.
+func cveGroupKey(m shared.VulnerabilityMatch) string {
+	return fmt.Sprintf("%d\x00%s\x00%s", m.UploadID, m.CVE, m.AffectedPackage.PackageName)
+}
+
+// preferred reports whether candidate's source should win the tie-break for
+// disagreeing scalar fields against bestRank, the rank of whichever source
+// is currently winning its CVE group, per sourcePriority. It returns the
+// rank candidate should be recorded under when it wins.
+func preferred(candidate shared.VulnerabilityMatch, bestRank int) (newRank int, win bool) {
+	if len(candidate.Sources) == 0 {
+		return bestRank, false
+	}
+
+	r := rank(candidate.Sources[0].SourceKind)
+	if bestRank == unranked || r < bestRank {
+		return r, true
+	}
+
+	return bestRank, false
+}
@@ -0,0 +1,124 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/codeintel/sentinel/shared"
+)
+
+func TestMergeByCVEKeepsDistinctUploadsSeparate(t *testing.T) {
+	matches := []shared.VulnerabilityMatch{
+		{
+			ID:       1,
+			UploadID: 1,
+			CVE:      "CVE-2021-1234",
+			AffectedPackage: shared.AffectedPackage{
+				PackageName: "lodash",
+			},
+			Sources: []shared.VulnerabilitySource{{SourceKind: "nvd"}},
+		},
+		{
+			ID:       2,
+			UploadID: 2,
+			CVE:      "CVE-2021-1234",
+			AffectedPackage: shared.AffectedPackage{
+				PackageName: "lodash",
+			},
+			Sources: []shared.VulnerabilitySource{{SourceKind: "nvd"}},
+		},
+	}
+
+	merged := mergeByCVE(matches)
+	if len(merged) != 2 {
+		t.Fatalf("expected both uploads' matches to survive, got %d: %+v", len(merged), merged)
+	}
+
+	uploadIDs := map[int]bool{}
+	for _, m := range merged {
+		uploadIDs[m.UploadID] = true
+	}
+	if !uploadIDs[1] || !uploadIDs[2] {
+		t.Fatalf("expected matches for upload 1 and upload 2, got %+v", merged)
+	}
+}
+
+func TestMergeByCVECollapsesSameUploadSources(t *testing.T) {
+	matches := []shared.VulnerabilityMatch{
+		{
+			ID:       1,
+			UploadID: 1,
+			CVE:      "CVE-2021-1234",
+			AffectedPackage: shared.AffectedPackage{
+				PackageName: "lodash",
+			},
+			Sources: []shared.VulnerabilitySource{{SourceKind: "ghsa"}},
+		},
+		{
+			ID:       2,
+			UploadID: 1,
+			CVE:      "CVE-2021-1234",
+			AffectedPackage: shared.AffectedPackage{
+				PackageName: "lodash",
+			},
+			Sources: []shared.VulnerabilitySource{{SourceKind: "nvd"}},
+		},
+	}
+
+	merged := mergeByCVE(matches)
+	if len(merged) != 1 {
+		t.Fatalf("expected the same upload's two sources to collapse into one match, got %d: %+v", len(merged), merged)
+	}
+	if len(merged[0].Sources) != 2 {
+		t.Fatalf("expected both sources to be retained on the merged match, got %+v", merged[0].Sources)
+	}
+}
+
+func TestMergeByCVETieBreaksAgainstBestRankSoFar(t *testing.T) {
+	fixedInEcosystem := "1.0.0-ecosystem"
+	fixedInNVD := "1.0.0-nvd"
+	fixedInGHSA := "1.0.0-ghsa"
+
+	// Arrival order ecosystem -> nvd -> ghsa: nvd should win over both
+	// ecosystem and ghsa, since it's the highest-priority source seen so
+	// far, not just higher priority than whichever source arrived first.
+	matches := []shared.VulnerabilityMatch{
+		{
+			ID:       1,
+			UploadID: 1,
+			CVE:      "CVE-2021-1234",
+			AffectedPackage: shared.AffectedPackage{
+				PackageName: "lodash",
+				FixedIn:     &fixedInEcosystem,
+			},
+			Sources: []shared.VulnerabilitySource{{SourceKind: "ecosystem"}},
+		},
+		{
+			ID:       2,
+			UploadID: 1,
+			CVE:      "CVE-2021-1234",
+			AffectedPackage: shared.AffectedPackage{
+				PackageName: "lodash",
+				FixedIn:     &fixedInNVD,
+			},
+			Sources: []shared.VulnerabilitySource{{SourceKind: "nvd"}},
+		},
+		{
+			ID:       3,
+			UploadID: 1,
+			CVE:      "CVE-2021-1234",
+			AffectedPackage: shared.AffectedPackage{
+				PackageName: "lodash",
+				FixedIn:     &fixedInGHSA,
+			},
+			Sources: []shared.VulnerabilitySource{{SourceKind: "ghsa"}},
+		},
+	}
+
+	merged := mergeByCVE(matches)
+	if len(merged) != 1 {
+		t.Fatalf("expected all three sources to collapse into one match, got %d: %+v", len(merged), merged)
+	}
+	if got := merged[0].AffectedPackage.FixedIn; got == nil || *got != fixedInNVD {
+		t.Fatalf("expected nvd's FixedIn to win the tie-break, got %v", got)
+	}
+}
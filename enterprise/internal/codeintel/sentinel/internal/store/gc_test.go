@@ -0,0 +1,167 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/keegancsmith/sqlf"
+	"github.com/sourcegraph/log/logtest"
+
+	"github.com/sourcegraph/sourcegraph/internal/database/basestore"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbtest"
+	"github.com/sourcegraph/sourcegraph/internal/observation"
+)
+
+func testStore(t *testing.T) *store {
+	logger := logtest.Scoped(t)
+	db := basestore.NewWithHandle(basestore.NewHandleWithDB(logger, dbtest.NewDB(logger, t), sql.TxOptions{}))
+
+	return &store{db: db, operations: newOperations(&observation.TestContext)}
+}
+
+// TestGCMatchesDeletesSupersededAffectedPackage inserts two update operations
+// for the same vulnerability (an old one and a retained, newer one), a match
+// tied to the old update operation's affected package, and confirms
+// GCMatches deletes that match once the newer update operation has
+// superseded it.
+func TestGCMatchesDeletesSupersededAffectedPackage(t *testing.T) {
+	ctx := context.Background()
+	s := testStore(t)
+
+	oldRef, err := s.WriteUpdateOperation(ctx, UpdateOperationVulnerability, "test-updater")
+	if err != nil {
+		t.Fatalf("unexpected error writing old update operation: %s", err)
+	}
+	newRef, err := s.WriteUpdateOperation(ctx, UpdateOperationVulnerability, "test-updater")
+	if err != nil {
+		t.Fatalf("unexpected error writing new update operation: %s", err)
+	}
+
+	vulnerabilityID := insertTestVulnerability(t, s)
+	oldVAPID := insertTestAffectedPackage(t, s, vulnerabilityID, "lodash", oldRef)
+	insertTestAffectedPackage(t, s, vulnerabilityID, "lodash", newRef)
+
+	uploadID := insertTestUpload(t, s)
+	insertTestMatch(t, s, uploadID, oldVAPID)
+
+	if err := s.GCMatches(ctx, 1); err != nil {
+		t.Fatalf("unexpected error from GCMatches: %s", err)
+	}
+
+	count, _, err := basestore.ScanFirstInt(s.db.Query(ctx, sqlf.Sprintf(
+		`SELECT COUNT(*) FROM vulnerability_matches WHERE vulnerability_affected_package_id = %s`,
+		oldVAPID,
+	)))
+	if err != nil {
+		t.Fatalf("unexpected error counting remaining matches: %s", err)
+	}
+	if count != 0 {
+		t.Fatalf("expected the match produced by the superseded update operation to be GC'd, but it still exists")
+	}
+}
+
+// TestGCMatchesIgnoresUploadKindRefsInRetentionWindow inserts an old and a
+// new vulnerability update operation, as in
+// TestGCMatchesDeletesSupersededAffectedPackage, but interleaves many more recent
+// upload-kind update operations in between. retainRefs is small enough that
+// the upload-kind noise alone would crowd the new vulnerability ref out of
+// a kind-agnostic retention window; GCMatches must still GC the old match.
+func TestGCMatchesIgnoresUploadKindRefsInRetentionWindow(t *testing.T) {
+	ctx := context.Background()
+	s := testStore(t)
+
+	oldRef, err := s.WriteUpdateOperation(ctx, UpdateOperationVulnerability, "test-updater")
+	if err != nil {
+		t.Fatalf("unexpected error writing old update operation: %s", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, err := s.WriteUpdateOperation(ctx, UpdateOperationUpload, "test-updater"); err != nil {
+			t.Fatalf("unexpected error writing upload update operation: %s", err)
+		}
+	}
+
+	newRef, err := s.WriteUpdateOperation(ctx, UpdateOperationVulnerability, "test-updater")
+	if err != nil {
+		t.Fatalf("unexpected error writing new update operation: %s", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, err := s.WriteUpdateOperation(ctx, UpdateOperationUpload, "test-updater"); err != nil {
+			t.Fatalf("unexpected error writing upload update operation: %s", err)
+		}
+	}
+
+	vulnerabilityID := insertTestVulnerability(t, s)
+	oldVAPID := insertTestAffectedPackage(t, s, vulnerabilityID, "lodash", oldRef)
+	insertTestAffectedPackage(t, s, vulnerabilityID, "lodash", newRef)
+
+	uploadID := insertTestUpload(t, s)
+	insertTestMatch(t, s, uploadID, oldVAPID)
+
+	if err := s.GCMatches(ctx, 1); err != nil {
+		t.Fatalf("unexpected error from GCMatches: %s", err)
+	}
+
+	count, _, err := basestore.ScanFirstInt(s.db.Query(ctx, sqlf.Sprintf(
+		`SELECT COUNT(*) FROM vulnerability_matches WHERE vulnerability_affected_package_id = %s`,
+		oldVAPID,
+	)))
+	if err != nil {
+		t.Fatalf("unexpected error counting remaining matches: %s", err)
+	}
+	if count != 0 {
+		t.Fatalf("expected the superseded match to be GC'd despite intervening upload-kind update operations, but it still exists")
+	}
+}
+
+func insertTestVulnerability(t *testing.T, s *store) int {
+	t.Helper()
+
+	id, _, err := basestore.ScanFirstInt(s.db.Query(context.Background(), sqlf.Sprintf(
+		`INSERT INTO vulnerabilities (source_id, source_kind, severity) VALUES (%s, %s, %s) RETURNING id`,
+		"TEST-0001", "nvd", "high",
+	)))
+	if err != nil {
+		t.Fatalf("unexpected error inserting vulnerability: %s", err)
+	}
+	return id
+}
+
+func insertTestAffectedPackage(t *testing.T, s *store, vulnerabilityID int, packageName string, updateOperationRef any) int {
+	t.Helper()
+
+	id, _, err := basestore.ScanFirstInt(s.db.Query(context.Background(), sqlf.Sprintf(
+		`INSERT INTO vulnerability_affected_packages (vulnerability_id, package_name, language, update_operation_ref) VALUES (%s, %s, %s, %s) RETURNING id`,
+		vulnerabilityID, packageName, "javascript", updateOperationRef,
+	)))
+	if err != nil {
+		t.Fatalf("unexpected error inserting affected package: %s", err)
+	}
+	return id
+}
+
+func insertTestUpload(t *testing.T, s *store) int {
+	t.Helper()
+
+	id, _, err := basestore.ScanFirstInt(s.db.Query(context.Background(), sqlf.Sprintf(
+		`INSERT INTO lsif_uploads (commit, root, indexer) VALUES (%s, %s, %s) RETURNING id`,
+		"deadbeef", "/", "test-indexer",
+	)))
+	if err != nil {
+		t.Fatalf("unexpected error inserting upload: %s", err)
+	}
+	return id
+}
+
+func insertTestMatch(t *testing.T, s *store, uploadID, vulnerabilityAffectedPackageID int) {
+	t.Helper()
+
+	if err := s.db.Exec(context.Background(), sqlf.Sprintf(
+		`INSERT INTO vulnerability_matches (upload_id, vulnerability_affected_package_id, reachability, match_confidence, matcher_type, fingerprint) VALUES (%s, %s, %s, %s, %s, %s)`,
+		uploadID, vulnerabilityAffectedPackageID, "reachable-package-only", "high", "npm", "test-fingerprint",
+	)); err != nil {
+		t.Fatalf("unexpected error inserting match: %s", err)
+	}
+}
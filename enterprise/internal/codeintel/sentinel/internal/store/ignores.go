@@ -0,0 +1,71 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/keegancsmith/sqlf"
+
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/codeintel/sentinel/shared"
+	"github.com/sourcegraph/sourcegraph/internal/database/basestore"
+	"github.com/sourcegraph/sourcegraph/internal/observation"
+)
+
+// ApplyIgnoreRule records a standing exception suppressing matches for
+// rule.VulnerabilityID against rule.PackageName, optionally scoped to a
+// single upload or repository and/or until rule.ExpiresAt. Matches it
+// covers are excluded from GetVulnerabilityMatches unless IncludeIgnored is
+// set, and resurface on their own once ExpiresAt passes without the rule
+// needing to be revoked.
+func (s *store) ApplyIgnoreRule(ctx context.Context, rule shared.IgnoreRule) (id int, err error) {
+	ctx, _, endObservation := s.operations.applyIgnoreRule.With(ctx, &err, observation.Args{})
+	defer endObservation(1, observation.Args{})
+
+	id, _, err = basestore.ScanFirstInt(s.db.Query(ctx, sqlf.Sprintf(
+		applyIgnoreRuleQuery,
+		rule.VulnerabilityID,
+		rule.PackageName,
+		rule.UploadID,
+		rule.RepoID,
+		rule.Reason,
+		rule.ExpiresAt,
+		rule.CreatedBy,
+	)))
+
+	return id, err
+}
+
+const applyIgnoreRuleQuery = `
+INSERT INTO vulnerability_match_ignores (vulnerability_id, package_name, upload_id, repo_id, reason, expires_at, created_by)
+VALUES (%s, %s, %s, %s, %s, %s, %s)
+RETURNING id
+`
+
+// RevokeIgnoreRule deletes the ignore rule with the given id. Matches it was
+// suppressing become visible again on the next GetVulnerabilityMatches call.
+func (s *store) RevokeIgnoreRule(ctx context.Context, id int) (err error) {
+	ctx, _, endObservation := s.operations.revokeIgnoreRule.With(ctx, &err, observation.Args{})
+	defer endObservation(1, observation.Args{})
+
+	return s.db.Exec(ctx, sqlf.Sprintf(revokeIgnoreRuleQuery, id))
+}
+
+const revokeIgnoreRuleQuery = `DELETE FROM vulnerability_match_ignores WHERE id = %s`
+
+// CountIgnoreRulesExpiringSoon reports how many ignore rules have an
+// expires_at falling within window of now. It backs the periodic gauge that
+// warns before an accepted-risk exception lapses unnoticed and its match
+// silently resurfaces.
+func (s *store) CountIgnoreRulesExpiringSoon(ctx context.Context, window time.Duration) (count int, err error) {
+	ctx, _, endObservation := s.operations.countIgnoreRulesExpiringSoon.With(ctx, &err, observation.Args{})
+	defer endObservation(1, observation.Args{})
+
+	count, _, err = basestore.ScanFirstInt(s.db.Query(ctx, sqlf.Sprintf(countIgnoreRulesExpiringSoonQuery, window/time.Second)))
+	return count, err
+}
+
+const countIgnoreRulesExpiringSoonQuery = `
+SELECT COUNT(*)
+FROM vulnerability_match_ignores
+WHERE expires_at IS NOT NULL AND expires_at BETWEEN NOW() AND NOW() + (%s * INTERVAL '1 second')
+`
@@ -0,0 +1,207 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/keegancsmith/sqlf"
+
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/codeintel/sentinel/shared"
+	"github.com/sourcegraph/sourcegraph/internal/database/basestore"
+)
+
+func TestApplyIgnoreRuleSuppressesAndRevokeRestoresMatch(t *testing.T) {
+	ctx := context.Background()
+	s := testStore(t)
+
+	vulnerabilityID := insertTestVulnerability(t, s)
+	vapID := insertTestAffectedPackage(t, s, vulnerabilityID, "lodash", nil)
+	uploadID := insertTestUpload(t, s)
+	insertTestMatch(t, s, uploadID, vapID)
+
+	matches, _, err := s.GetVulnerabilityMatches(ctx, shared.GetVulnerabilityMatchesArgs{Limit: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(matches) != 1 {
+		t.Fatalf("expected 1 match before any ignore rule, got %d", len(matches))
+	}
+
+	ruleID, err := s.ApplyIgnoreRule(ctx, shared.IgnoreRule{
+		VulnerabilityID: vulnerabilityID,
+		PackageName:     "lodash",
+		Reason:          "accepted risk",
+		CreatedBy:       "test-user",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error applying ignore rule: %s", err)
+	}
+
+	matches, _, err = s.GetVulnerabilityMatches(ctx, shared.GetVulnerabilityMatchesArgs{Limit: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(matches) != 0 {
+		t.Fatalf("expected the ignore rule to suppress the match, got %d matches", len(matches))
+	}
+
+	matches, _, err = s.GetVulnerabilityMatches(ctx, shared.GetVulnerabilityMatchesArgs{Limit: 10, IncludeIgnored: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(matches) != 1 || !matches[0].Ignored || matches[0].IgnoreReason == nil || *matches[0].IgnoreReason != "accepted risk" {
+		t.Fatalf("expected 1 ignored match tagged with its reason, got %+v", matches)
+	}
+
+	if err := s.RevokeIgnoreRule(ctx, ruleID); err != nil {
+		t.Fatalf("unexpected error revoking ignore rule: %s", err)
+	}
+
+	matches, _, err = s.GetVulnerabilityMatches(ctx, shared.GetVulnerabilityMatchesArgs{Limit: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(matches) != 1 {
+		t.Fatalf("expected the match to resurface once its ignore rule was revoked, got %d matches", len(matches))
+	}
+}
+
+func TestIgnoreRuleResurfacesOnceExpired(t *testing.T) {
+	ctx := context.Background()
+	s := testStore(t)
+
+	vulnerabilityID := insertTestVulnerability(t, s)
+	vapID := insertTestAffectedPackage(t, s, vulnerabilityID, "lodash", nil)
+	uploadID := insertTestUpload(t, s)
+	insertTestMatch(t, s, uploadID, vapID)
+
+	expired := time.Now().Add(-time.Hour)
+	if _, err := s.ApplyIgnoreRule(ctx, shared.IgnoreRule{
+		VulnerabilityID: vulnerabilityID,
+		PackageName:     "lodash",
+		Reason:          "fix in flight",
+		CreatedBy:       "test-user",
+		ExpiresAt:       &expired,
+	}); err != nil {
+		t.Fatalf("unexpected error applying ignore rule: %s", err)
+	}
+
+	matches, _, err := s.GetVulnerabilityMatches(ctx, shared.GetVulnerabilityMatchesArgs{Limit: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(matches) != 1 {
+		t.Fatalf("expected the match to resurface on its own once expires_at passed, got %d matches", len(matches))
+	}
+}
+
+func TestIgnoreRuleScopedToUploadDoesNotSuppressOtherUploads(t *testing.T) {
+	ctx := context.Background()
+	s := testStore(t)
+
+	vulnerabilityID := insertTestVulnerability(t, s)
+	vapID := insertTestAffectedPackage(t, s, vulnerabilityID, "lodash", nil)
+
+	ignoredUploadID := insertTestUpload(t, s)
+	insertTestMatch(t, s, ignoredUploadID, vapID)
+
+	otherUploadID := insertTestUpload(t, s)
+	insertTestMatch(t, s, otherUploadID, vapID)
+
+	if _, err := s.ApplyIgnoreRule(ctx, shared.IgnoreRule{
+		VulnerabilityID: vulnerabilityID,
+		PackageName:     "lodash",
+		UploadID:        &ignoredUploadID,
+		Reason:          "accepted risk for this upload only",
+		CreatedBy:       "test-user",
+	}); err != nil {
+		t.Fatalf("unexpected error applying ignore rule: %s", err)
+	}
+
+	matches, _, err := s.GetVulnerabilityMatches(ctx, shared.GetVulnerabilityMatchesArgs{Limit: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(matches) != 1 || matches[0].UploadID != otherUploadID {
+		t.Fatalf("expected only the other upload's match to remain visible, got %+v", matches)
+	}
+}
+
+func TestIgnoreRuleScopedToRepoDoesNotSuppressOtherRepos(t *testing.T) {
+	ctx := context.Background()
+	s := testStore(t)
+
+	vulnerabilityID := insertTestVulnerability(t, s)
+	vapID := insertTestAffectedPackage(t, s, vulnerabilityID, "lodash", nil)
+
+	ignoredRepoID := 1
+	otherRepoID := 2
+
+	ignoredUploadID := insertTestUploadForRepo(t, s, ignoredRepoID)
+	insertTestMatch(t, s, ignoredUploadID, vapID)
+
+	otherUploadID := insertTestUploadForRepo(t, s, otherRepoID)
+	insertTestMatch(t, s, otherUploadID, vapID)
+
+	if _, err := s.ApplyIgnoreRule(ctx, shared.IgnoreRule{
+		VulnerabilityID: vulnerabilityID,
+		PackageName:     "lodash",
+		RepoID:          &ignoredRepoID,
+		Reason:          "accepted risk for this repo only",
+		CreatedBy:       "test-user",
+	}); err != nil {
+		t.Fatalf("unexpected error applying ignore rule: %s", err)
+	}
+
+	matches, _, err := s.GetVulnerabilityMatches(ctx, shared.GetVulnerabilityMatchesArgs{Limit: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(matches) != 1 || matches[0].UploadID != otherUploadID {
+		t.Fatalf("expected only the other repo's match to remain visible, got %+v", matches)
+	}
+}
+
+func TestCountIgnoreRulesExpiringSoon(t *testing.T) {
+	ctx := context.Background()
+	s := testStore(t)
+
+	vulnerabilityID := insertTestVulnerability(t, s)
+
+	soon := time.Now().Add(3 * 24 * time.Hour)
+	later := time.Now().Add(30 * 24 * time.Hour)
+
+	for _, expiresAt := range []*time.Time{&soon, &later, nil} {
+		if _, err := s.ApplyIgnoreRule(ctx, shared.IgnoreRule{
+			VulnerabilityID: vulnerabilityID,
+			PackageName:     "lodash",
+			Reason:          "test",
+			CreatedBy:       "test-user",
+			ExpiresAt:       expiresAt,
+		}); err != nil {
+			t.Fatalf("unexpected error applying ignore rule: %s", err)
+		}
+	}
+
+	count, err := s.CountIgnoreRulesExpiringSoon(ctx, 7*24*time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if count != 1 {
+		t.Fatalf("expected only the rule expiring within the 7 day window to be counted, got %d", count)
+	}
+}
+
+func insertTestUploadForRepo(t *testing.T, s *store, repositoryID int) int {
+	t.Helper()
+
+	id, _, err := basestore.ScanFirstInt(s.db.Query(context.Background(), sqlf.Sprintf(
+		`INSERT INTO lsif_uploads (commit, root, indexer, repository_id) VALUES (%s, %s, %s, %s) RETURNING id`,
+		"deadbeef", "/", "test-indexer", repositoryID,
+	)))
+	if err != nil {
+		t.Fatalf("unexpected error inserting upload: %s", err)
+	}
+	return id
+}
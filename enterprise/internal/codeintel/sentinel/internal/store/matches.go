@@ -2,13 +2,12 @@ package store
 
 import (
 	"context"
-	"sort"
-	"strings"
 
-	"github.com/hashicorp/go-version"
+	"github.com/google/uuid"
 	"github.com/keegancsmith/sqlf"
 	"github.com/lib/pq"
 
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/codeintel/sentinel/internal/matcher"
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/codeintel/sentinel/shared"
 	"github.com/sourcegraph/sourcegraph/internal/database/basestore"
 	"github.com/sourcegraph/sourcegraph/internal/database/batch"
@@ -16,7 +15,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/observation"
 )
 
-func (s *store) VulnerabilityMatchByID(ctx context.Context, id int) (_ shared.VulnerabilityMatch, _ bool, err error) {
+func (s *store) VulnerabilityMatchByID(ctx context.Context, id int, orientByCVE bool) (_ shared.VulnerabilityMatch, _ bool, err error) {
 	ctx, _, endObservation := s.operations.vulnerabilityMatchByID.With(ctx, &err, observation.Args{})
 	defer endObservation(1, observation.Args{})
 
@@ -25,6 +24,10 @@ func (s *store) VulnerabilityMatchByID(ctx context.Context, id int) (_ shared.Vu
 		return shared.VulnerabilityMatch{}, false, err
 	}
 
+	if orientByCVE {
+		matches = mergeByCVE(matches)
+	}
+
 	return matches[0], true, nil
 }
 
@@ -35,10 +38,21 @@ SELECT
 	vap.vulnerability_id,
 	` + vulnerabilityAffectedPackageFields + `,
 	` + vulnerabilityAffectedSymbolFields + `,
+	m.reachability,
+	m.matcher_type,
+	m.fingerprint,
+	i.id IS NOT NULL AS ignored,
+	i.reason AS ignore_reason,
+	v.source_kind,
+	v.severity,
+	` + canonicalCVEAliasSubquery + `,
 	0 AS count
 FROM vulnerability_matches m
 LEFT JOIN vulnerability_affected_packages vap ON vap.id = m.vulnerability_affected_package_id
+LEFT JOIN lsif_uploads u ON u.id = m.upload_id
 LEFT JOIN vulnerability_affected_symbols vas ON vas.vulnerability_affected_package_id = vap.id
+LEFT JOIN vulnerabilities v ON v.id = vap.vulnerability_id
+LEFT JOIN vulnerability_match_ignores i ON ` + ignoreRuleJoinCondition + `
 WHERE m.id = %s
 `
 
@@ -46,7 +60,27 @@ func (s *store) GetVulnerabilityMatches(ctx context.Context, args shared.GetVuln
 	ctx, _, endObservation := s.operations.getVulnerabilityMatches.With(ctx, &err, observation.Args{})
 	defer endObservation(1, observation.Args{})
 
-	return scanVulnerabilityMatchesAndCount(s.db.Query(ctx, sqlf.Sprintf(getVulnerabilityMatchesQuery, args.Limit, args.Offset)))
+	conds := []*sqlf.Query{sqlf.Sprintf("TRUE")}
+	if args.Reachability != "" {
+		conds = append(conds, sqlf.Sprintf("m.reachability = %s", args.Reachability))
+	}
+
+	matches, totalCount, err := scanVulnerabilityMatchesAndCount(s.db.Query(ctx, sqlf.Sprintf(
+		getVulnerabilityMatchesQuery,
+		sqlf.Join(conds, " AND "),
+		args.IncludeIgnored,
+		args.Limit,
+		args.Offset,
+	)))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if args.OrientByCVE {
+		matches = mergeByCVE(matches)
+	}
+
+	return matches, totalCount, nil
 }
 
 const getVulnerabilityMatchesQuery = `
@@ -55,8 +89,17 @@ WITH limited_matches AS (
 		m.id,
 		m.upload_id,
 		m.vulnerability_affected_package_id,
+		m.reachability,
+		m.matcher_type,
+		m.fingerprint,
+		i.id IS NOT NULL AS ignored,
+		i.reason AS ignore_reason,
 		COUNT(*) OVER() AS count
 	FROM vulnerability_matches m
+	JOIN vulnerability_affected_packages vap ON vap.id = m.vulnerability_affected_package_id
+	JOIN lsif_uploads u ON u.id = m.upload_id
+	LEFT JOIN vulnerability_match_ignores i ON ` + ignoreRuleJoinCondition + `
+	WHERE %s AND (%s OR i.id IS NULL)
 	ORDER BY id
 	LIMIT %s OFFSET %s
 )
@@ -66,13 +109,43 @@ SELECT
 	vap.vulnerability_id,
 	` + vulnerabilityAffectedPackageFields + `,
 	` + vulnerabilityAffectedSymbolFields + `,
+	m.reachability,
+	m.matcher_type,
+	m.fingerprint,
+	m.ignored,
+	m.ignore_reason,
+	v.source_kind,
+	v.severity,
+	` + canonicalCVEAliasSubquery + `,
 	m.count
 FROM limited_matches m
 LEFT JOIN vulnerability_affected_packages vap ON vap.id = m.vulnerability_affected_package_id
 LEFT JOIN vulnerability_affected_symbols vas ON vas.vulnerability_affected_package_id = vap.id
+LEFT JOIN vulnerabilities v ON v.id = vap.vulnerability_id
 ORDER BY m.id, vap.id, vas.id
 `
 
+// canonicalCVEAliasSubquery picks a single CVE-* alias per vulnerability as
+// a scalar subquery rather than a LEFT JOIN, since vulnerability_aliases is
+// one-to-many off the same vulnerability and joining it directly would
+// cross it against the also one-to-many vulnerability_affected_symbols join,
+// duplicating every affected symbol once per extra alias.
+const canonicalCVEAliasSubquery = `
+	(SELECT alias FROM vulnerability_aliases WHERE vulnerability_id = v.id AND alias LIKE 'CVE-%%' ORDER BY alias LIMIT 1) AS alias
+`
+
+// ignoreRuleJoinCondition matches a vulnerability_matches row against any
+// ignore rule still in effect for it: same vulnerability and package, not
+// yet expired, and not scoped to a different upload or repository than
+// this one.
+const ignoreRuleJoinCondition = `
+	i.vulnerability_id = vap.vulnerability_id AND
+	i.package_name = vap.package_name AND
+	(i.upload_id IS NULL OR i.upload_id = m.upload_id) AND
+	(i.repo_id IS NULL OR i.repo_id = u.repository_id) AND
+	(i.expires_at IS NULL OR i.expires_at > NOW())
+`
+
 var flattenMatches = func(ms []shared.VulnerabilityMatch) []shared.VulnerabilityMatch {
 	flattened := []shared.VulnerabilityMatch{}
 	for _, m := range ms {
@@ -96,9 +169,12 @@ var flattenMatches = func(ms []shared.VulnerabilityMatch) []shared.Vulnerability
 var scanVulnerabilityMatchesAndCount = func(rows basestore.Rows, queryErr error) ([]shared.VulnerabilityMatch, int, error) {
 	matches, totalCount, err := basestore.NewSliceWithCountScanner(func(s dbutil.Scanner) (match shared.VulnerabilityMatch, count int, _ error) {
 		var (
-			vap     shared.AffectedPackage
-			vas     shared.AffectedSymbol
-			fixedIn string
+			vap          shared.AffectedPackage
+			vas          shared.AffectedSymbol
+			fixedIn      string
+			ignoreReason string
+			sourceKind   string
+			severity     string
 		)
 
 		if err := s.Scan(
@@ -114,6 +190,14 @@ var scanVulnerabilityMatchesAndCount = func(rows basestore.Rows, queryErr error)
 			&dbutil.NullString{S: &fixedIn},
 			&dbutil.NullString{S: &vas.Path},
 			pq.Array(vas.Symbols),
+			&match.Reachability,
+			&dbutil.NullString{S: &match.MatcherType},
+			&dbutil.NullString{S: &match.Fingerprint},
+			&match.Ignored,
+			&dbutil.NullString{S: &ignoreReason},
+			&dbutil.NullString{S: &sourceKind},
+			&dbutil.NullString{S: &severity},
+			&dbutil.NullString{S: &match.CVE},
 			&count,
 		); err != nil {
 			return shared.VulnerabilityMatch{}, 0, err
@@ -122,12 +206,24 @@ var scanVulnerabilityMatchesAndCount = func(rows basestore.Rows, queryErr error)
 		if fixedIn != "" {
 			vap.FixedIn = &fixedIn
 		}
+		if ignoreReason != "" {
+			match.IgnoreReason = &ignoreReason
+		}
 		if vas.Path != "" {
 			vap.AffectedSymbols = append(vap.AffectedSymbols, vas)
 		}
 		if vap.PackageName != "" {
 			match.AffectedPackage = vap
 		}
+		if sourceKind != "" {
+			match.Sources = []shared.VulnerabilitySource{{
+				VulnerabilityID:   match.VulnerabilityID,
+				SourceKind:        sourceKind,
+				Severity:          severity,
+				FixedIn:           vap.FixedIn,
+				VersionConstraint: vap.VersionConstraint,
+			}}
+		}
 
 		return match, count, nil
 	})(rows, queryErr)
@@ -135,10 +231,52 @@ var scanVulnerabilityMatchesAndCount = func(rows basestore.Rows, queryErr error)
 		return nil, 0, err
 	}
 
-	return flattenMatches(matches), totalCount, nil
+	return dedupeByFingerprint(flattenMatches(matches)), totalCount, nil
+}
+
+// dedupeByFingerprint drops matches whose Fingerprint repeats an earlier
+// match in ms, keeping the first occurrence. Since the fingerprint hashes
+// the matcher type, only repeats from the same matcher are dropped; the
+// same pair reported by two different matchers is kept twice. Matches
+// without a fingerprint
+// (rows inserted before this column existed) are never deduped away.
+func dedupeByFingerprint(ms []shared.VulnerabilityMatch) []shared.VulnerabilityMatch {
+	seen := make(map[string]bool, len(ms))
+	deduped := make([]shared.VulnerabilityMatch, 0, len(ms))
+	for _, m := range ms {
+		if m.Fingerprint != "" {
+			if seen[m.Fingerprint] {
+				continue
+			}
+			seen[m.Fingerprint] = true
+		}
+		deduped = append(deduped, m)
+	}
+
+	return deduped
 }
 
-func (s *store) ScanMatches(ctx context.Context) (err error) {
+// ScanMatches recomputes vulnerability_matches rows, but only for the
+// subset of (upload, affected package) pairs that could have changed since
+// sinceRef: uploads landed after sinceRef (against every affected package),
+// and affected packages ingested after sinceRef (against every upload).
+// Pairs that are unchanged on both sides were already considered by an
+// earlier call and are skipped, keeping the cost of a call proportional to
+// what changed rather than to the full size of either table. Pass
+// uuid.Nil to scan everything, e.g. on first run.
+//
+// Each candidate pair is dispatched to s.matcher, which picks the
+// ecosystem-specific Matcher registered for the dependency's SCIP scheme
+// (falling back to matcher.Stock for schemes nothing else claims) rather
+// than the previous hardcoded scheme-to-language map. Every resulting match
+// is then classified by classifyReachability, consulting the candidate
+// upload's SCIP index to distinguish uploads that merely depend on a
+// vulnerable package from uploads that actually invoke one of its affected
+// symbols; matches classified unreachable are dropped rather than written.
+// Reachability and matcher provenance are computed and written together in
+// one pass so there is a single writer of vulnerability_matches, instead of
+// two independent passes racing to own the same row.
+func (s *store) ScanMatches(ctx context.Context, sinceRef uuid.UUID) (err error) {
 	ctx, _, endObservation := s.operations.scanMatches.With(ctx, &err, observation.Args{})
 	defer endObservation(1, observation.Args{})
 
@@ -148,31 +286,59 @@ func (s *store) ScanMatches(ctx context.Context) (err error) {
 	}
 	defer func() { err = tx.Done(err) }()
 
-	scipSchemeToVulnerabilityLanguage := map[string]string{
-		"gomod": "go",
-		"npm":   "Javascript",
-		// TODO - java mapping
+	sinceCond := sqlf.Sprintf("TRUE")
+	if sinceRef != uuid.Nil {
+		sinceCond = sqlf.Sprintf(scanMatchesSinceRefCondition, sinceRef, sinceRef)
 	}
 
-	schemes := make([]string, 0, len(scipSchemeToVulnerabilityLanguage))
-	for scheme := range scipSchemeToVulnerabilityLanguage {
-		schemes = append(schemes, scheme)
-	}
-	sort.Strings(schemes)
-
-	mappings := make([]*sqlf.Query, 0, len(schemes))
-	for _, scheme := range schemes {
-		mappings = append(mappings, sqlf.Sprintf("(r.scheme = %s AND vap.language = %s)", scheme, scipSchemeToVulnerabilityLanguage[scheme]))
-	}
-
-	matches, err := scanFilteredVulnerabilityMatches(tx.Query(ctx, sqlf.Sprintf(
+	// No scheme pre-filter: candidates for schemes with no registered
+	// Matcher still need to reach matcher.Stock's fallback.
+	candidates, err := scanCandidateRows(tx.Query(ctx, sqlf.Sprintf(
 		scanMatchesQuery,
-		sqlf.Join(mappings, " OR "),
+		sqlf.Sprintf("TRUE"),
+		sinceCond,
 	)))
 	if err != nil {
 		return err
 	}
 
+	type classifiedResult struct {
+		matcher.Result
+		reachability string
+	}
+
+	results := make([]classifiedResult, 0, len(candidates))
+	for _, candidate := range candidates {
+		rowResults, err := s.matcher.Dispatch(ctx, tx, candidate.affectedPackage(), candidate.upload())
+		if err != nil {
+			return err
+		}
+
+		// Dedup within this candidate's own results only: a fingerprint now
+		// encodes (upload, affected package, ...), so two different
+		// candidates never legitimately collide here. Deduping across
+		// candidates would drop real matches for every upload past the
+		// first that happens to depend on the same vulnerable package at
+		// the same version.
+		seenFingerprints := make(map[string]bool, len(rowResults))
+		for _, result := range rowResults {
+			if seenFingerprints[result.Fingerprint] {
+				continue
+			}
+			seenFingerprints[result.Fingerprint] = true
+
+			reachability, err := classifyReachability(ctx, tx, result)
+			if err != nil {
+				return err
+			}
+			if reachability == unreachable {
+				continue
+			}
+
+			results = append(results, classifiedResult{Result: result, reachability: reachability})
+		}
+	}
+
 	if err := tx.Exec(ctx, sqlf.Sprintf(scanMatchesTemporaryTableQuery)); err != nil {
 		return err
 	}
@@ -185,13 +351,21 @@ func (s *store) ScanMatches(ctx context.Context) (err error) {
 		[]string{
 			"upload_id",
 			"vulnerability_affected_package_id",
+			"reachability",
+			"match_confidence",
+			"matcher_type",
+			"fingerprint",
 		},
 		func(inserter *batch.Inserter) error {
-			for _, match := range matches {
+			for _, result := range results {
 				if err := inserter.Insert(
 					ctx,
-					match.UploadID,
-					match.VulnerabilityAffectedPackageID,
+					result.UploadID,
+					result.VulnerabilityAffectedPackageID,
+					result.reachability,
+					result.Confidence,
+					result.MatcherType,
+					result.Fingerprint,
 				); err != nil {
 					return err
 				}
@@ -203,69 +377,112 @@ func (s *store) ScanMatches(ctx context.Context) (err error) {
 		return err
 	}
 
-	if err := tx.Exec(ctx, sqlf.Sprintf(scanMatchesUpdateQuery)); err != nil {
-		return err
-	}
-
-	return nil
+	return tx.Exec(ctx, sqlf.Sprintf(scanMatchesUpdateQuery))
 }
 
 const scanMatchesQuery = `
 SELECT
 	r.dump_id,
-	vap.id,
+	r.scheme,
+	r.name,
 	r.version,
+	vap.id,
+	vap.package_name,
+	vap.language,
+	vap.namespace,
 	vap.version_constraint
 FROM vulnerability_affected_packages vap
 -- TODO - do we need the inverse? need to refine? the resulting match?
 JOIN lsif_references r ON r.name LIKE '%%' || vap.package_name || '%%'
-WHERE %s
+JOIN lsif_uploads u ON u.id = r.dump_id
+WHERE (%s) AND (%s)
+`
+
+// scanMatchesSinceRefCondition restricts scanMatchesQuery to pairs where at
+// least one side is new since sinceRef: the upload landed after sinceRef,
+// or the affected package was ingested after sinceRef. Pairs where both
+// sides predate sinceRef were already considered by a prior call.
+const scanMatchesSinceRefCondition = `
+(
+	u.update_operation_ref IN (
+		SELECT ref FROM update_operations
+		WHERE created_at > (SELECT created_at FROM update_operations WHERE ref = %s)
+	)
+	OR
+	vap.update_operation_ref IN (
+		SELECT ref FROM update_operations
+		WHERE created_at > (SELECT created_at FROM update_operations WHERE ref = %s)
+	)
+)
 `
 
 const scanMatchesTemporaryTableQuery = `
 CREATE TEMPORARY TABLE t_vulnerability_affected_packages (
-	upload_id                          INT NOT NULL,
-	vulnerability_affected_package_id  INT NOT NULL
+	upload_id                          INT  NOT NULL,
+	vulnerability_affected_package_id  INT  NOT NULL,
+	reachability                       TEXT NOT NULL,
+	match_confidence                   TEXT NOT NULL,
+	matcher_type                       TEXT NOT NULL,
+	fingerprint                        TEXT NOT NULL
 ) ON COMMIT DROP
 `
 
+// scanMatchesUpdateQuery upserts on (upload_id, vulnerability_affected_package_id)
+// so a later call with fresher SCIP data can update a match's reachability,
+// matcher type and fingerprint in place instead of leaving a stale row
+// behind.
 const scanMatchesUpdateQuery = `
-INSERT INTO vulnerability_matches (upload_id, vulnerability_affected_package_id)
-SELECT upload_id, vulnerability_affected_package_id FROM t_vulnerability_affected_packages
-ON CONFLICT DO NOTHING
+INSERT INTO vulnerability_matches (upload_id, vulnerability_affected_package_id, reachability, match_confidence, matcher_type, fingerprint)
+SELECT upload_id, vulnerability_affected_package_id, reachability, match_confidence, matcher_type, fingerprint FROM t_vulnerability_affected_packages
+ON CONFLICT (upload_id, vulnerability_affected_package_id) DO UPDATE SET
+	reachability = EXCLUDED.reachability,
+	match_confidence = EXCLUDED.match_confidence,
+	matcher_type = EXCLUDED.matcher_type,
+	fingerprint = EXCLUDED.fingerprint
 `
 
-type VulnerabilityMatch struct {
-	UploadID                       int
-	VulnerabilityAffectedPackageID int
+// candidateRow is a single (upload, affected package) pair produced by the
+// name-substring join in scanMatchesQuery, before any matcher has judged
+// whether it's a real match.
+type candidateRow struct {
+	uploadID               int
+	scheme                 string
+	name                   string
+	version                string
+	vulnerabilityPackageID int
+	packageName            string
+	language               string
+	namespace              string
+	versionConstraint      []string
 }
 
-var scanFilteredVulnerabilityMatches = basestore.NewFilteredSliceScanner(func(s dbutil.Scanner) (m VulnerabilityMatch, _ bool, _ error) {
-	var (
-		version            string
-		versionConstraints []string
-	)
-
-	if err := s.Scan(&m.UploadID, &m.VulnerabilityAffectedPackageID, &version, pq.Array(&versionConstraints)); err != nil {
-		return VulnerabilityMatch{}, false, err
-	}
-
-	matches, valid := versionMatchesConstraints(version, versionConstraints)
-	_ = valid // TODO - log un-parseable versions
-
-	return m, matches, nil
-})
-
-func versionMatchesConstraints(versionString string, constraints []string) (matches, valid bool) {
-	v, err := version.NewVersion(versionString)
-	if err != nil {
-		return false, false
-	}
+func (r candidateRow) upload() matcher.Upload {
+	return matcher.Upload{ID: r.uploadID, Scheme: r.scheme, Name: r.name, Version: r.version}
+}
 
-	constraint, err := version.NewConstraint(strings.Join(constraints, ","))
-	if err != nil {
-		return false, false
+func (r candidateRow) affectedPackage() shared.AffectedPackage {
+	return shared.AffectedPackage{
+		ID:                r.vulnerabilityPackageID,
+		PackageName:       r.packageName,
+		Language:          r.language,
+		Namespace:         r.namespace,
+		VersionConstraint: r.versionConstraint,
 	}
+}
 
-	return constraint.Check(v), true
+func scanCandidateRows(rows basestore.Rows, queryErr error) ([]candidateRow, error) {
+	return basestore.NewSliceScanner(func(sc dbutil.Scanner) (r candidateRow, _ error) {
+		err := sc.Scan(
+			&r.uploadID,
+			&r.scheme,
+			&r.name,
+			&r.version,
+			&r.vulnerabilityPackageID,
+			&r.packageName,
+			&r.language,
+			&r.namespace,
+			pq.Array(&r.versionConstraint),
+		)
+		return r, err
+	})(rows, queryErr)
 }
\ No newline at end of file
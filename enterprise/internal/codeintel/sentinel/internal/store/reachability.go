@@ -0,0 +1,72 @@
+package store
+
+import (
+	"context"
+
+	"github.com/keegancsmith/sqlf"
+	"github.com/lib/pq"
+
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/codeintel/sentinel/internal/matcher"
+	"github.com/sourcegraph/sourcegraph/internal/database/basestore"
+)
+
+// queryer is satisfied by both s.db (*basestore.Store) and the transaction
+// handle returned by s.db.Transact; classifyReachability only needs to run read queries against
+// whichever one it's handed.
+type queryer interface {
+	Query(ctx context.Context, query *sqlf.Query) (basestore.Rows, error)
+}
+
+// classifyReachability determines the reachability of a single candidate
+// match. If the vulnerability's affected package does not name any affected
+// symbols, the match is package-level reachable by definition. Otherwise the
+// candidate upload's SCIP occurrences are walked for a reference (as opposed
+// to a definition) resolving to one of the named symbols.
+func classifyReachability(ctx context.Context, db queryer, candidate matcher.Result) (string, error) {
+	symbols, err := basestore.ScanStrings(db.Query(ctx, sqlf.Sprintf(affectedSymbolNamesQuery, candidate.VulnerabilityAffectedPackageID)))
+	if err != nil {
+		return "", err
+	}
+	if len(symbols) == 0 {
+		return reachablePackageOnly, nil
+	}
+
+	ok, _, err := basestore.ScanFirstBool(db.Query(ctx, sqlf.Sprintf(
+		scipSymbolReferencedQuery,
+		candidate.UploadID,
+		pq.Array(symbols),
+	)))
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return unreachable, nil
+	}
+
+	return reachableSymbol, nil
+}
+
+const (
+	reachableSymbol      = "reachable-symbol"
+	reachablePackageOnly = "reachable-package-only"
+	unreachable          = "unreachable"
+)
+
+const affectedSymbolNamesQuery = `
+SELECT unnest(symbols) FROM vulnerability_affected_symbols WHERE vulnerability_affected_package_id = %s
+`
+
+// scipSymbolReferencedQuery checks whether the given upload's SCIP index
+// contains at least one occurrence of one of the candidate symbols whose
+// role is a reference (i.e. the definition bit is unset).
+const scipSymbolReferencedQuery = `
+SELECT EXISTS (
+	SELECT 1
+	FROM codeintel_scip_symbols ss
+	JOIN codeintel_scip_symbol_names sn ON sn.id = ss.symbol_id
+	WHERE
+		ss.upload_id = %s AND
+		sn.symbol_name = ANY(%s) AND
+		(ss.symbol_roles & 1) = 0 -- role bit 1 is "definition"; references leave it unset
+)
+`
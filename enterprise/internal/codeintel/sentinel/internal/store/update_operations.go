@@ -0,0 +1,79 @@
+package store
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/keegancsmith/sqlf"
+
+	"github.com/sourcegraph/sourcegraph/internal/observation"
+)
+
+// UpdateOperationKind distinguishes the two things that can make a
+// previously-computed vulnerability match stale: new vulnerability data
+// landing, or a new LSIF upload landing.
+type UpdateOperationKind string
+
+const (
+	UpdateOperationVulnerability UpdateOperationKind = "vulnerability"
+	UpdateOperationUpload        UpdateOperationKind = "upload"
+)
+
+// WriteUpdateOperation records that a batch of vulnerability or upload data
+// has landed, returning a ref that ScanMatches' sinceRef argument and
+// GCMatches' retention logic can be anchored to. Callers write one of these
+// after ingesting vulnerabilities and after an upload's references are
+// recorded.
+func (s *store) WriteUpdateOperation(ctx context.Context, kind UpdateOperationKind, updater string) (ref uuid.UUID, err error) {
+	ctx, _, endObservation := s.operations.writeUpdateOperation.With(ctx, &err, observation.Args{})
+	defer endObservation(1, observation.Args{})
+
+	ref = uuid.New()
+	err = s.db.Exec(ctx, sqlf.Sprintf(writeUpdateOperationQuery, ref, kind, updater))
+	return ref, err
+}
+
+const writeUpdateOperationQuery = `
+INSERT INTO update_operations (ref, kind, updater, created_at) VALUES (%s, %s, %s, NOW())
+`
+
+// GCMatches deletes vulnerability_matches rows whose affected package has
+// since been superseded by a later vulnerability update operation and is no
+// longer reachable from any of the most recent retainRefs vulnerability
+// update operations. This is what keeps the matcher store's size bounded under
+// continuous ingestion instead of growing with every historical version of
+// every vulnerability.
+func (s *store) GCMatches(ctx context.Context, retainRefs int) (err error) {
+	ctx, _, endObservation := s.operations.gcMatches.With(ctx, &err, observation.Args{})
+	defer endObservation(1, observation.Args{})
+
+	return s.db.Exec(ctx, sqlf.Sprintf(gcMatchesQuery, retainRefs))
+}
+
+// gcMatchesQuery deletes matches whose vulnerability_affected_package_id
+// belongs to a row from an update operation older than the retainRefs most
+// recent ones, and for which a newer update operation has since produced a
+// replacement row for the same vulnerability (i.e. the old row is
+// genuinely superseded, not just old). retained_refs only looks at
+// vulnerability-kind update operations, since vap.update_operation_ref is
+// always one of those; upload-kind operations land far more often and would
+// otherwise dominate the LIMIT and push genuinely current vulnerability
+// operations out of the retention window.
+const gcMatchesQuery = `
+WITH retained_refs AS (
+	SELECT ref FROM update_operations WHERE kind = 'vulnerability' ORDER BY created_at DESC LIMIT %s
+)
+DELETE FROM vulnerability_matches m
+USING vulnerability_affected_packages vap
+WHERE
+	m.vulnerability_affected_package_id = vap.id AND
+	vap.update_operation_ref NOT IN (SELECT ref FROM retained_refs) AND
+	EXISTS (
+		SELECT 1
+		FROM vulnerability_affected_packages newer
+		WHERE
+			newer.vulnerability_id = vap.vulnerability_id AND
+			newer.id != vap.id AND
+			newer.update_operation_ref IN (SELECT ref FROM retained_refs)
+	)
+`
@@ -0,0 +1,34 @@
+package versionmatch
+
+import (
+	"golang.org/x/mod/semver"
+)
+
+// goEcosystem matches Go module versions, including pseudo-versions
+// (v0.0.0-20210101000000-abcdef123456), which are valid semver and so need
+// no special-casing beyond what golang.org/x/mod/semver already provides.
+type goEcosystem struct{}
+
+type goVersion string
+
+func (v goVersion) Compare(other Version) int {
+	return semver.Compare(string(v), string(other.(goVersion)))
+}
+
+func (v goVersion) String() string { return string(v) }
+
+func (goEcosystem) Parse(raw string) (Version, error) {
+	canonical := raw
+	if len(canonical) == 0 || canonical[0] != 'v' {
+		canonical = "v" + canonical
+	}
+	if !semver.IsValid(canonical) {
+		return nil, ErrUnparseableVersion{Ecosystem: "go", Raw: raw}
+	}
+
+	return goVersion(canonical), nil
+}
+
+func (e goEcosystem) Check(v Version, c Constraint) bool {
+	return checkEvents(func(raw string) (Version, error) { return e.Parse(raw) }, v, c)
+}
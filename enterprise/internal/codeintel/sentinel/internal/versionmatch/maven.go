@@ -0,0 +1,40 @@
+package versionmatch
+
+import (
+	mvnversion "github.com/masahiro331/go-mvn-version"
+)
+
+// mavenEcosystem matches Maven coordinate versions per Maven's
+// ComparableVersion ordering, where qualifiers like -SNAPSHOT sort before
+// the corresponding release and numeric/alphanumeric segments compare
+// differently than semver's.
+type mavenEcosystem struct{}
+
+type mavenVersionWrapper struct{ v mvnversion.Version }
+
+func (w mavenVersionWrapper) Compare(other Version) int {
+	o := other.(mavenVersionWrapper).v
+	switch {
+	case w.v.LessThan(o):
+		return -1
+	case w.v.Equal(o):
+		return 0
+	default:
+		return 1
+	}
+}
+
+func (w mavenVersionWrapper) String() string { return w.v.String() }
+
+func (mavenEcosystem) Parse(raw string) (Version, error) {
+	v, err := mvnversion.NewVersion(raw)
+	if err != nil {
+		return nil, ErrUnparseableVersion{Ecosystem: "Maven", Raw: raw, Cause: err}
+	}
+
+	return mavenVersionWrapper{v}, nil
+}
+
+func (e mavenEcosystem) Check(v Version, c Constraint) bool {
+	return checkEvents(func(raw string) (Version, error) { return e.Parse(raw) }, v, c)
+}
@@ -0,0 +1,32 @@
+package versionmatch
+
+import (
+	npmversion "github.com/aquasecurity/go-npm-version/pkg"
+)
+
+// npmEcosystem matches npm/Javascript package versions per node-semver
+// rules, which are semver-like but permit things our old hashicorp/go-version
+// parsing rejected outright, such as build metadata on prerelease versions
+// (1.0.0-beta.2+build).
+type npmEcosystem struct{}
+
+type npmVersionWrapper struct{ v npmversion.Version }
+
+func (w npmVersionWrapper) Compare(other Version) int {
+	return w.v.Compare(other.(npmVersionWrapper).v)
+}
+
+func (w npmVersionWrapper) String() string { return w.v.String() }
+
+func (npmEcosystem) Parse(raw string) (Version, error) {
+	v, err := npmversion.NewVersion(raw)
+	if err != nil {
+		return nil, ErrUnparseableVersion{Ecosystem: "npm", Raw: raw, Cause: err}
+	}
+
+	return npmVersionWrapper{v}, nil
+}
+
+func (e npmEcosystem) Check(v Version, c Constraint) bool {
+	return checkEvents(func(raw string) (Version, error) { return e.Parse(raw) }, v, c)
+}
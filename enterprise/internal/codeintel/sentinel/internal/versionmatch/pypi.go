@@ -0,0 +1,31 @@
+package versionmatch
+
+import (
+	pep440 "github.com/aquasecurity/go-pep440-version"
+)
+
+// pypiEcosystem matches PyPI package versions per PEP 440, so that
+// pre/post/dev-release segments (1.0.0a1, 1.0.0.post1, 1.0.0.dev0) order
+// the way pip and OSV expect rather than being rejected as invalid semver.
+type pypiEcosystem struct{}
+
+type pypiVersionWrapper struct{ v pep440.Version }
+
+func (w pypiVersionWrapper) Compare(other Version) int {
+	return w.v.Compare(other.(pypiVersionWrapper).v)
+}
+
+func (w pypiVersionWrapper) String() string { return w.v.String() }
+
+func (pypiEcosystem) Parse(raw string) (Version, error) {
+	v, err := pep440.Parse(raw)
+	if err != nil {
+		return nil, ErrUnparseableVersion{Ecosystem: "PyPI", Raw: raw, Cause: err}
+	}
+
+	return pypiVersionWrapper{v}, nil
+}
+
+func (e pypiEcosystem) Check(v Version, c Constraint) bool {
+	return checkEvents(func(raw string) (Version, error) { return e.Parse(raw) }, v, c)
+}
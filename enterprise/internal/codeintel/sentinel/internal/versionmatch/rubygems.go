@@ -0,0 +1,30 @@
+package versionmatch
+
+import (
+	gemversion "github.com/aquasecurity/go-gem-version"
+)
+
+// rubyGemsEcosystem matches RubyGems versions per RubyGems::Version
+// ordering rules.
+type rubyGemsEcosystem struct{}
+
+type gemVersionWrapper struct{ v gemversion.Version }
+
+func (w gemVersionWrapper) Compare(other Version) int {
+	return w.v.Compare(other.(gemVersionWrapper).v)
+}
+
+func (w gemVersionWrapper) String() string { return w.v.String() }
+
+func (rubyGemsEcosystem) Parse(raw string) (Version, error) {
+	v, err := gemversion.NewVersion(raw)
+	if err != nil {
+		return nil, ErrUnparseableVersion{Ecosystem: "RubyGems", Raw: raw, Cause: err}
+	}
+
+	return gemVersionWrapper{v}, nil
+}
+
+func (e rubyGemsEcosystem) Check(v Version, c Constraint) bool {
+	return checkEvents(func(raw string) (Version, error) { return e.Parse(raw) }, v, c)
+}
@@ -0,0 +1,180 @@
+// Package versionmatch checks whether a dependency's resolved version falls
+// within an OSV-style affected range, per ecosystem-specific version
+// ordering rules. hashicorp/go-version implements a single (semver-ish)
+// ordering for every ecosystem, which silently misparses or misorders valid
+// versions from ecosystems that don't follow semver (PEP 440, Maven, Go
+// pseudo-versions, and even semver-adjacent-but-not-quite npm ranges).
+package versionmatch
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Version is a parsed, ecosystem-specific version that can be ordered
+// against other versions parsed by the same Ecosystem.
+type Version interface {
+	// Compare returns -1, 0, or 1 if the receiver sorts before, equal to,
+	// or after other, respectively.
+	Compare(other Version) int
+	String() string
+}
+
+// Event is a single OSV range event. Exactly one field is set, mirroring the
+// shape of an entry in an OSV `affected[].ranges[].events` array.
+type Event struct {
+	Introduced   string
+	Fixed        string
+	LastAffected string
+}
+
+// Constraint is an OSV-style affected range: an ordered sequence of
+// introduced/fixed/last_affected events that partitions the version line
+// into affected and unaffected half-open intervals. Events must be sorted
+// ascending by the version they name; an empty Introduced ("") means
+// "from the beginning of time".
+type Constraint struct {
+	Events []Event
+}
+
+// Ecosystem knows how to parse and order versions for a single OSV
+// ecosystem (e.g. "Go", "npm", "PyPI", "Maven", "RubyGems").
+type Ecosystem interface {
+	// Parse parses a raw version string in this ecosystem's native format.
+	Parse(raw string) (Version, error)
+	// Check reports whether v falls within the affected range described by
+	// c, per OSV's introduced/fixed/last_affected interval semantics.
+	Check(v Version, c Constraint) bool
+}
+
+// byEcosystem holds the registered Ecosystem implementations, keyed by the
+// lowercased ecosystem name that normalize maps a language or ecosystem
+// name onto.
+var byEcosystem = map[string]Ecosystem{
+	"go":       goEcosystem{},
+	"npm":      npmEcosystem{},
+	"pypi":     pypiEcosystem{},
+	"maven":    mavenEcosystem{},
+	"rubygems": rubyGemsEcosystem{},
+}
+
+// Lookup returns the Ecosystem registered for the given language, matched
+// case-insensitively (OSV and our own `language` column disagree on case,
+// e.g. "Javascript" vs "npm").
+func Lookup(language string) (Ecosystem, bool) {
+	e, ok := byEcosystem[normalize(language)]
+	return e, ok
+}
+
+func normalize(language string) string {
+	lowered := strings.ToLower(language)
+	switch lowered {
+	case "javascript", "npm":
+		return "npm"
+	case "python", "pypi":
+		return "pypi"
+	case "go", "gomod":
+		return "go"
+	case "maven", "java":
+		return "maven"
+	case "ruby", "rubygems":
+		return "rubygems"
+	default:
+		return lowered
+	}
+}
+
+// checkEvents walks a parsed Constraint's events in order, tracking whether
+// v currently falls inside an affected interval. This is shared by every
+// Ecosystem's Check implementation so that only version parsing/ordering
+// differs between ecosystems; the interval semantics are OSV's and don't
+// vary by ecosystem.
+func checkEvents(parse func(string) (Version, error), v Version, c Constraint) bool {
+	affected := false
+	for _, event := range c.Events {
+		switch {
+		case event.Fixed == "" && event.LastAffected == "":
+			// An introduced event, possibly with an empty version.
+			introduced, err := parseEventVersion(parse, event.Introduced)
+			if err != nil {
+				continue
+			}
+			if introduced == nil || v.Compare(introduced) >= 0 {
+				affected = true
+			}
+		case event.Fixed != "":
+			fixed, err := parse(event.Fixed)
+			if err != nil {
+				continue
+			}
+			if v.Compare(fixed) >= 0 {
+				affected = false
+			}
+		case event.LastAffected != "":
+			lastAffected, err := parse(event.LastAffected)
+			if err != nil {
+				continue
+			}
+			if v.Compare(lastAffected) > 0 {
+				affected = false
+			}
+		}
+	}
+
+	return affected
+}
+
+// parseEventVersion parses event, treating the OSV convention of an empty
+// "introduced" string as negative infinity (i.e. always satisfied).
+func parseEventVersion(parse func(string) (Version, error), raw string) (Version, error) {
+	if raw == "0" || raw == "" {
+		return nil, nil
+	}
+
+	return parse(raw)
+}
+
+// ParseConstraintEvents decodes a vulnerability_affected_packages.version_constraint
+// entry into an OSV-style Constraint. Each element is a "kind:version" pair
+// (e.g. "introduced:0", "fixed:2.0.0", "last_affected:1.9.9") mirroring the
+// events of an OSV affected.ranges[] entry; this is how the ingestion
+// pipeline stores ranges verbatim instead of collapsing them into a single
+// constraint expression.
+func ParseConstraintEvents(raw []string) (Constraint, error) {
+	c := Constraint{Events: make([]Event, 0, len(raw))}
+
+	for _, entry := range raw {
+		kind, version, ok := strings.Cut(entry, ":")
+		if !ok {
+			return Constraint{}, fmt.Errorf("malformed version constraint event %q", entry)
+		}
+
+		switch kind {
+		case "introduced":
+			c.Events = append(c.Events, Event{Introduced: version})
+		case "fixed":
+			c.Events = append(c.Events, Event{Fixed: version})
+		case "last_affected":
+			c.Events = append(c.Events, Event{LastAffected: version})
+		default:
+			return Constraint{}, fmt.Errorf("unrecognized version constraint event kind %q", kind)
+		}
+	}
+
+	return c, nil
+}
+
+// ErrUnparseableVersion is wrapped into the error returned by an Ecosystem's
+// Parse method when the input does not conform to that ecosystem's version
+// grammar.
+type ErrUnparseableVersion struct {
+	Ecosystem string
+	Raw       string
+	Cause     error
+}
+
+func (e ErrUnparseableVersion) Error() string {
+	return fmt.Sprintf("%s: could not parse %q as a %s version: %v", e.Ecosystem, e.Raw, e.Ecosystem, e.Cause)
+}
+
+func (e ErrUnparseableVersion) Unwrap() error { return e.Cause }
@@ -0,0 +1,135 @@
+package shared
+
+import "time"
+
+// VulnerabilityMatch associates an LSIF upload with a vulnerability affecting
+// a package that upload depends on.
+type VulnerabilityMatch struct {
+	ID              int
+	UploadID        int
+	VulnerabilityID int
+	AffectedPackage AffectedPackage
+	Reachability    Reachability
+
+	// MatcherType names the matcher.Matcher implementation that produced
+	// this match (e.g. "gomod", "npm", "maven", "stock").
+	MatcherType string
+	// Fingerprint identifies this match's (upload, affected package, package
+	// name, version, matcher) identity, so the same match reported again by
+	// the same matcher collapses into one result. Matches reported by two
+	// different matchers have different fingerprints.
+	Fingerprint string
+
+	// CVE is the canonical CVE identifier this match's vulnerability_id is
+	// aliased to, if any.
+	CVE string
+	// Sources lists the advisories (NVD, GHSA, ecosystem, ...) that
+	// contributed to this match: the match's own advisory, plus those of
+	// every match merged into it when collapsed by CVE (OrientByCVE).
+	Sources []VulnerabilitySource
+
+	// Ignored is true if an unexpired IgnoreRule covers this match.
+	// GetVulnerabilityMatches only returns such matches when
+	// GetVulnerabilityMatchesArgs.IncludeIgnored is set, and excludes them
+	// otherwise; VulnerabilityMatchByID always returns the match, tagged.
+	Ignored bool
+	// IgnoreReason is the reason recorded on the IgnoreRule that covers
+	// this match, if Ignored is true.
+	IgnoreReason *string
+}
+
+// IgnoreRule is a standing exception suppressing vulnerability matches
+// against packageName under vulnerabilityID, optionally narrowed to a
+// single upload or repository and/or until a point in time. It maps the
+// CVE-exception workflow (a team has accepted the risk, or a fix is
+// already in flight) onto the match store so the exception is reviewable
+// and auditable rather than a silent code-level allowlist.
+type IgnoreRule struct {
+	ID              int
+	VulnerabilityID int
+	PackageName     string
+	// UploadID, if set, scopes this rule to a single upload rather than
+	// every upload depending on PackageName.
+	UploadID *int
+	// RepoID, if set, scopes this rule to uploads belonging to a single
+	// repository.
+	RepoID    *int
+	Reason    string
+	CreatedBy string
+	CreatedAt time.Time
+	// ExpiresAt, if set, is when this rule stops applying. A match it was
+	// suppressing resurfaces on its own once ExpiresAt passes, without the
+	// rule needing to be revoked.
+	ExpiresAt *time.Time
+}
+
+// VulnerabilitySource is the per-advisory view of a CVE-oriented match:
+// the same CVE as reported by one specific source, each of which may
+// disagree on severity or the exact version range affected.
+type VulnerabilitySource struct {
+	VulnerabilityID   int
+	SourceKind        string // e.g. "nvd", "ghsa", "ecosystem"
+	Severity          string
+	FixedIn           *string
+	VersionConstraint []string
+}
+
+// Reachability classifies how confident we are that an upload actually
+// invokes the vulnerable code named by a match, as opposed to merely
+// depending on the affected package.
+type Reachability string
+
+const (
+	// ReachableSymbol means the upload contains a reference to one of the
+	// symbols named in the vulnerability's AffectedSymbols.
+	ReachableSymbol Reachability = "reachable-symbol"
+	// ReachablePackageOnly means the vulnerability does not name any
+	// affected symbols, so the match falls back to the package-level
+	// heuristic.
+	ReachablePackageOnly Reachability = "reachable-package-only"
+	// Unreachable means the upload's SCIP symbols were consulted and none of
+	// the named affected symbols are referenced. An upload with no SCIP
+	// symbol data is classified this way too.
+	Unreachable Reachability = "unreachable"
+)
+
+// AffectedPackage describes the portion of a vulnerability record that is
+// specific to a single affected package.
+type AffectedPackage struct {
+	ID                int
+	PackageName       string
+	Language          string
+	Namespace         string
+	VersionConstraint []string
+	Fixed             bool
+	FixedIn           *string
+	AffectedSymbols   []AffectedSymbol
+}
+
+// AffectedSymbol describes a single symbol (or set of symbols declared in the
+// same file) named by a vulnerability affected package record.
+type AffectedSymbol struct {
+	Path    string
+	Symbols []string
+}
+
+// GetVulnerabilityMatchesArgs configures a call to GetVulnerabilityMatches.
+type GetVulnerabilityMatchesArgs struct {
+	Limit  int
+	Offset int
+
+	// Reachability, when non-empty, restricts results to matches with
+	// exactly this reachability classification.
+	Reachability Reachability
+
+	// OrientByCVE, when set, collapses matches whose vulnerability_id
+	// aliases share a canonical CVE into a single result, merging their
+	// per-source fields into Sources instead of returning one match per
+	// underlying advisory.
+	OrientByCVE bool
+
+	// IncludeIgnored, when set, includes matches covered by an unexpired
+	// IgnoreRule in the results (tagged via Ignored/IgnoreReason) instead
+	// of excluding them.
+	IncludeIgnored bool
+}